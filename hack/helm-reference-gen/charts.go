@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// subchartDocs walks chartsDir/*/values.yaml and renders each one as its
+// own markdown section, headed by an H2 with the chart's title and scoped
+// under its directory name so its anchors don't collide with the parent
+// chart's. This lets the umbrella chart ship a single reference page that
+// covers connect-inject, CNI, API gateway, etc. alongside the top-level
+// values.yaml.
+func subchartDocs(chartsDir, minVersion string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(chartsDir, "*", "values.yaml"))
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(matches)
+
+	var sections []string
+	for _, valuesPath := range matches {
+		dir := filepath.Dir(valuesPath)
+		sourceFile := filepath.Base(dir)
+
+		valuesBytes, err := ioutil.ReadFile(valuesPath)
+		if err != nil {
+			return "", err
+		}
+
+		docs, err := GenerateDocsForChart(string(valuesBytes), sourceFile, minVersion)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", valuesPath, err)
+		}
+
+		sections = append(sections, fmt.Sprintf("## %s\n\n%s", chartTitle(dir, sourceFile), docs))
+	}
+	return strings.Join(sections, "\n\n"), nil
+}
+
+// chartTitle returns the "name" field from dir/Chart.yaml, falling back to
+// fallback (the chart's directory name) if Chart.yaml is missing or
+// doesn't set a name.
+func chartTitle(dir, fallback string) string {
+	chartBytes, err := ioutil.ReadFile(filepath.Join(dir, "Chart.yaml"))
+	if err != nil {
+		return fallback
+	}
+
+	var chart struct {
+		Name string `yaml:"name"`
+	}
+	if err := yaml.Unmarshal(chartBytes, &chart); err != nil || chart.Name == "" {
+		return fallback
+	}
+	return chart.Name
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChartTitle(t *testing.T) {
+	dir := t.TempDir()
+
+	if got := chartTitle(dir, "fallback"); got != "fallback" {
+		t.Errorf("with no Chart.yaml: got %q, want %q", got, "fallback")
+	}
+
+	chartYaml := "name: consul-connect-inject\n"
+	if err := os.WriteFile(filepath.Join(dir, "Chart.yaml"), []byte(chartYaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := chartTitle(dir, "fallback"); got != "consul-connect-inject" {
+		t.Errorf("with Chart.yaml name set: got %q, want %q", got, "consul-connect-inject")
+	}
+}
+
+func TestSubchartDocs(t *testing.T) {
+	chartsDir := t.TempDir()
+
+	connectInjectDir := filepath.Join(chartsDir, "connect-inject")
+	if err := os.MkdirAll(connectInjectDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(connectInjectDir, "values.yaml"), []byte("enabled: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(connectInjectDir, "Chart.yaml"), []byte("name: Connect Inject\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	apiGatewayDir := filepath.Join(chartsDir, "api-gateway")
+	if err := os.MkdirAll(apiGatewayDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(apiGatewayDir, "values.yaml"), []byte("enabled: false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := subchartDocs(chartsDir, "")
+	if err != nil {
+		t.Fatalf("subchartDocs: %v", err)
+	}
+
+	if !strings.Contains(out, "## Connect Inject") {
+		t.Errorf("expected a header using the Chart.yaml name, got:\n%s", out)
+	}
+	if !strings.Contains(out, "## api-gateway") {
+		t.Errorf("expected a header falling back to the directory name, got:\n%s", out)
+	}
+	if strings.Index(out, "## api-gateway") > strings.Index(out, "## Connect Inject") {
+		t.Errorf("expected sections sorted by directory name (api-gateway before connect-inject), got:\n%s", out)
+	}
+	if !strings.Contains(out, "connect-inject-enabled") {
+		t.Errorf("expected anchors scoped under the subchart's directory name, got:\n%s", out)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	// NOTE: this package has no go.mod/go.sum in this tree, so
+	// go-difflib isn't declared anywhere. `go mod tidy` (or an
+	// equivalent go.sum update) needs to run once this dependency is
+	// vendored for real.
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const (
+	// referenceStart and referenceEnd demarcate the auto-generated block
+	// inside the published markdown reference. Everything between them is
+	// replaced wholesale by -write and compared against by runCheck.
+	referenceStart = "<!-- START HELM REFERENCE -->"
+	referenceEnd   = "<!-- END HELM REFERENCE -->"
+)
+
+// runCheck implements the "check" subcommand: it regenerates the markdown
+// reference from values.yaml (and, like the default markdown output, any
+// charts/*/values.yaml under -charts-dir, pruned by -min-version) and
+// compares it against the block of mdPath between
+// referenceStart/referenceEnd. If they disagree it prints a unified diff
+// and exits non-zero, unless -write is passed, in which case it splices the
+// freshly generated block back into mdPath instead.
+func runCheck(args []string) {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	write := fs.Bool("write", false, "splice the freshly generated reference back into the file instead of diffing")
+	chartsDir := fs.String("charts-dir", "", "also document every charts/*/values.yaml found under this directory, each under its own H2 header")
+	minVersion := fs.String("min-version", "", "leave out keys whose @since annotation is newer than this chart version (keys without @since are always included)")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		log.Fatal("usage: go run ./... check [--write] [--charts-dir=dir] [--min-version=version] <path-to-markdown-file>")
+	}
+	mdPath := fs.Arg(0)
+
+	generated, err := generateReference(*chartsDir, *minVersion)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mdBytes, err := ioutil.ReadFile(mdPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	updated, current, err := spliceReferenceBlock(string(mdBytes), generated)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if current == generated {
+		return
+	}
+
+	if *write {
+		if err := ioutil.WriteFile(mdPath, []byte(updated), 0644); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	diff := difflib.UnifiedDiff{
+		A:        difflib.SplitLines(current),
+		B:        difflib.SplitLines(generated),
+		FromFile: mdPath,
+		ToFile:   mdPath + " (regenerated)",
+		Context:  3,
+	}
+	diffText, err := difflib.GetUnifiedDiffString(diff)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(diffText)
+	fmt.Fprintf(os.Stderr, "%s is out of sync with values.yaml, run with -write to regenerate it\n", mdPath)
+	os.Exit(1)
+}
+
+// spliceReferenceBlock replaces the content between referenceStart and
+// referenceEnd in markdown with generated, returning both the updated
+// document and the block's current content so the caller can tell whether
+// anything actually changed.
+func spliceReferenceBlock(markdown, generated string) (updated string, current string, err error) {
+	startIdx := strings.Index(markdown, referenceStart)
+	endIdx := strings.Index(markdown, referenceEnd)
+	if startIdx == -1 || endIdx == -1 || endIdx < startIdx {
+		return "", "", fmt.Errorf("could not find %q / %q sentinels", referenceStart, referenceEnd)
+	}
+
+	contentStart := startIdx + len(referenceStart)
+	current = strings.Trim(markdown[contentStart:endIdx], "\n")
+	updated = markdown[:contentStart] + "\n" + generated + "\n" + markdown[endIdx:]
+	return updated, current, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestSpliceReferenceBlock(t *testing.T) {
+	markdown := "# Helm Reference\n\n" +
+		referenceStart + "\nold content\n" + referenceEnd +
+		"\n\n## Footer\n"
+
+	updated, current, err := spliceReferenceBlock(markdown, "new content")
+	if err != nil {
+		t.Fatalf("spliceReferenceBlock: %v", err)
+	}
+
+	if current != "old content" {
+		t.Errorf("current = %q, want %q", current, "old content")
+	}
+
+	want := "# Helm Reference\n\n" +
+		referenceStart + "\nnew content\n" + referenceEnd +
+		"\n\n## Footer\n"
+	if updated != want {
+		t.Errorf("updated = %q, want %q", updated, want)
+	}
+}
+
+func TestSpliceReferenceBlock_MissingSentinels(t *testing.T) {
+	cases := map[string]string{
+		"missing start": "no sentinels here\n" + referenceEnd,
+		"missing end":   referenceStart + "\nno end sentinel\n",
+		"reversed":      referenceEnd + "\n...\n" + referenceStart,
+	}
+	for name, markdown := range cases {
+		if _, _, err := spliceReferenceBlock(markdown, "generated"); err == nil {
+			t.Errorf("%s: expected an error, got nil", name)
+		}
+	}
+}
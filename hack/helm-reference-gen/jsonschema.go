@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// jsonSchema is a (subset of a) JSON Schema document. It only implements
+// the keywords values.schema.json needs: enough for chart users to get
+// IDE-level validation of values.yaml.
+type jsonSchema struct {
+	Type        string                 `json:"type,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Default     json.RawMessage        `json:"default,omitempty"`
+	Properties  map[string]*jsonSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	Items       *jsonSchema            `json:"items,omitempty"`
+	Enum        []json.RawMessage      `json:"enum,omitempty"`
+	Minimum     *float64               `json:"minimum,omitempty"`
+	Maximum     *float64               `json:"maximum,omitempty"`
+	Pattern     string                 `json:"pattern,omitempty"`
+}
+
+// jsonSchemaType maps a yaml.Node tag, e.g. "!!str", onto a JSON Schema
+// "type" keyword.
+func jsonSchemaType(kindTag string) string {
+	switch kindTag {
+	case "!!str":
+		return "string"
+	case "!!int":
+		return "integer"
+	case "!!bool":
+		return "boolean"
+	case "!!seq":
+		return "array"
+	case "!!map", "":
+		return "object"
+	default:
+		return "string"
+	}
+}
+
+// GenerateJSONSchema reads yamlStr and produces a Helm-compatible
+// values.schema.json, mirroring the structure GenerateDocs renders as
+// markdown.
+func GenerateJSONSchema(yamlStr string) (string, error) {
+	node, err := Parse(yamlStr, "")
+	if err != nil {
+		return "", err
+	}
+
+	root := buildObjectSchema(node.Children)
+
+	out, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// buildJSONSchema converts a single DocNode, and recursively its children,
+// into a jsonSchema node.
+func buildJSONSchema(node DocNode) *jsonSchema {
+	schema := &jsonSchema{
+		Type:        jsonSchemaType(node.KindTag),
+		Description: node.FormattedDocumentation(),
+		Minimum:     node.Minimum,
+		Maximum:     node.Maximum,
+		Pattern:     node.Pattern,
+	}
+	if len(node.Enum) > 0 {
+		enum := make([]json.RawMessage, 0, len(node.Enum))
+		for _, v := range node.Enum {
+			enum = append(enum, jsonScalar(v, schema.Type))
+		}
+		schema.Enum = enum
+	}
+
+	switch schema.Type {
+	case "object":
+		objSchema := buildObjectSchema(node.Children)
+		schema.Properties = objSchema.Properties
+		schema.Required = objSchema.Required
+
+	case "array":
+		// node.Children holds the flattened properties of the sequence's
+		// representative element (the same shape the markdown renderer
+		// uses, see the ingressGateways comment in parseNodeContent), not
+		// one DocNode per array entry. So the items sub-schema is an
+		// object built from all of them, not just the first.
+		if len(node.Children) > 0 {
+			schema.Items = buildObjectSchema(node.Children)
+		} else if raw := node.FormattedDefault(); raw != "" {
+			// A sequence of scalars, e.g. `tolerations: []` or
+			// `extraLabels: [a, b]`, has no Children at all (see the
+			// allScalars branch in buildDocNode), so its only renderable
+			// constraint is the default itself.
+			schema.Default = jsonArrayDefault(raw)
+		}
+
+	default:
+		if raw := node.FormattedDefault(); raw != "" {
+			schema.Default = jsonScalar(raw, schema.Type)
+		}
+	}
+
+	return schema
+}
+
+// jsonScalar converts raw, a plain-text scalar as found in values.yaml (or
+// from an explicit @default/@enum annotation), into the JSON value typ
+// calls for, so values.schema.json carries a real bool/number rather than
+// a string for every default and enum member. It falls back to a JSON
+// string if raw doesn't parse as typ.
+func jsonScalar(raw, typ string) json.RawMessage {
+	var v interface{} = raw
+	switch typ {
+	case "boolean":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			v = b
+		}
+	case "integer":
+		if i, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			v = i
+		}
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+// jsonArrayDefault converts raw, the inline YAML flow text of a scalar
+// sequence (e.g. "[]" or "[a, b]"), into its JSON equivalent. Unlike JSON,
+// YAML flow sequences don't require their string elements to be quoted, so
+// raw can't be used as JSON as-is.
+func jsonArrayDefault(raw string) json.RawMessage {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(raw), &v); err != nil {
+		return nil
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+// buildObjectSchema builds an object jsonSchema from a flat list of
+// property DocNodes, shared by GenerateJSONSchema's root and
+// buildJSONSchema's "object"/"array" cases.
+func buildObjectSchema(children []DocNode) *jsonSchema {
+	schema := &jsonSchema{
+		Type:       "object",
+		Properties: map[string]*jsonSchema{},
+	}
+	for _, child := range children {
+		schema.Properties[child.Key] = buildJSONSchema(child)
+		if child.Required {
+			schema.Required = append(schema.Required, child.Key)
+		}
+	}
+	return schema
+}
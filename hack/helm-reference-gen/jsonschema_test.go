@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestGenerateJSONSchema_ArrayOfMultiKeyMaps verifies that the items
+// sub-schema for an array of maps includes every property of the
+// representative element, not just the first.
+func TestGenerateJSONSchema_ArrayOfMultiKeyMaps(t *testing.T) {
+	yaml := `server:
+  ports:
+  - name: http
+    port: 8500
+`
+	out, err := GenerateJSONSchema(yaml)
+	if err != nil {
+		t.Fatalf("GenerateJSONSchema: %v", err)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &schema); err != nil {
+		t.Fatalf("unmarshal schema: %v", err)
+	}
+
+	ports := digest(t, schema, "properties", "server", "properties", "ports")
+	if got := ports["type"]; got != "array" {
+		t.Fatalf("expected ports.type == array, got %v", got)
+	}
+
+	items, ok := ports["items"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected ports.items to be an object, got %v", ports["items"])
+	}
+	if got := items["type"]; got != "object" {
+		t.Fatalf("expected ports.items.type == object, got %v", got)
+	}
+
+	props, ok := items["properties"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected ports.items.properties to be an object, got %v", items["properties"])
+	}
+	for _, key := range []string{"name", "port"} {
+		if _, ok := props[key]; !ok {
+			t.Errorf("expected ports.items.properties to contain %q, got %v", key, props)
+		}
+	}
+}
+
+// TestGenerateJSONSchema_TypedDefaults verifies that scalar defaults are
+// emitted as their real JSON type (bool/number) rather than as strings.
+func TestGenerateJSONSchema_TypedDefaults(t *testing.T) {
+	yaml := `enabled: true
+replicas: 3
+name: consul
+`
+	out, err := GenerateJSONSchema(yaml)
+	if err != nil {
+		t.Fatalf("GenerateJSONSchema: %v", err)
+	}
+
+	var schema struct {
+		Properties map[string]struct {
+			Type    string          `json:"type"`
+			Default json.RawMessage `json:"default"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal([]byte(out), &schema); err != nil {
+		t.Fatalf("unmarshal schema: %v", err)
+	}
+
+	cases := []struct {
+		key  string
+		want string
+	}{
+		{"enabled", "true"},
+		{"replicas", "3"},
+		{"name", `"consul"`},
+	}
+	for _, c := range cases {
+		prop, ok := schema.Properties[c.key]
+		if !ok {
+			t.Fatalf("missing property %q", c.key)
+		}
+		if got := string(prop.Default); got != c.want {
+			t.Errorf("%s.default = %s, want %s", c.key, got, c.want)
+		}
+	}
+}
+
+// TestGenerateJSONSchema_ScalarArrayDefault verifies that a sequence of
+// scalars still carries its default, even though it has no DocNode
+// Children to build an items schema from.
+func TestGenerateJSONSchema_ScalarArrayDefault(t *testing.T) {
+	yaml := `tolerations: []
+extraLabels: [a, b]
+`
+	out, err := GenerateJSONSchema(yaml)
+	if err != nil {
+		t.Fatalf("GenerateJSONSchema: %v", err)
+	}
+
+	var schema struct {
+		Properties map[string]struct {
+			Type    string          `json:"type"`
+			Default json.RawMessage `json:"default"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal([]byte(out), &schema); err != nil {
+		t.Fatalf("unmarshal schema: %v", err)
+	}
+
+	var tolerations []interface{}
+	if err := json.Unmarshal(schema.Properties["tolerations"].Default, &tolerations); err != nil {
+		t.Fatalf("unmarshal tolerations.default: %v", err)
+	}
+	if len(tolerations) != 0 {
+		t.Errorf("tolerations.default = %v, want []", tolerations)
+	}
+
+	var extraLabels []string
+	if err := json.Unmarshal(schema.Properties["extraLabels"].Default, &extraLabels); err != nil {
+		t.Fatalf("unmarshal extraLabels.default: %v", err)
+	}
+	if want := []string{"a", "b"}; len(extraLabels) != len(want) || extraLabels[0] != want[0] || extraLabels[1] != want[1] {
+		t.Errorf("extraLabels.default = %v, want %v", extraLabels, want)
+	}
+}
+
+// digest walks a nested map by successive keys, failing the test if any
+// step isn't present or isn't itself a map.
+func digest(t *testing.T, m map[string]interface{}, path ...string) map[string]interface{} {
+	t.Helper()
+	for _, key := range path {
+		next, ok := m[key]
+		if !ok {
+			t.Fatalf("missing key %q in %v", key, m)
+		}
+		m, ok = next.(map[string]interface{})
+		if !ok {
+			t.Fatalf("value at %q is not an object: %v", key, next)
+		}
+	}
+	return m
+}
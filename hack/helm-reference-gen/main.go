@@ -2,10 +2,13 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -27,6 +30,45 @@ var (
 	// recurseAnnotation matches the @recurse annotation. It captures the value of @recurse.
 	recurseAnnotation = regexp.MustCompile(`(?m).*@recurse: (.*)$`)
 
+	// enumAnnotation matches the @enum annotation. It captures a
+	// comma-separated list of the allowed values.
+	enumAnnotation = regexp.MustCompile(`(?m).*@enum: (.*)$`)
+
+	// requiredAnnotation matches the @required annotation. It captures the
+	// value of @required.
+	requiredAnnotation = regexp.MustCompile(`(?m).*@required: (.*)$`)
+
+	// minimumAnnotation matches the @minimum annotation. It captures the
+	// value of @minimum.
+	minimumAnnotation = regexp.MustCompile(`(?m).*@minimum: (.*)$`)
+
+	// maximumAnnotation matches the @maximum annotation. It captures the
+	// value of @maximum.
+	maximumAnnotation = regexp.MustCompile(`(?m).*@maximum: (.*)$`)
+
+	// patternAnnotation matches the @pattern annotation. It captures the
+	// value of @pattern.
+	patternAnnotation = regexp.MustCompile(`(?m).*@pattern: (.*)$`)
+
+	// deprecatedAnnotation matches the @deprecated annotation. It captures
+	// the deprecation message.
+	deprecatedAnnotation = regexp.MustCompile(`(?m).*@deprecated: (.*)$`)
+
+	// sinceAnnotation matches the @since annotation. It captures the chart
+	// version the key was introduced in.
+	sinceAnnotation = regexp.MustCompile(`(?m).*@since: (.*)$`)
+
+	// annotationLine matches an entire annotation line (including its
+	// trailing newline) so it can be stripped out of a comment before the
+	// comment is rendered as documentation.
+	annotationLine = regexp.MustCompile(`(?m)^.*@[a-zA-Z]+: .*\n?`)
+
+	// groupHeaderLine matches a "# --- Group Name ---" divider comment
+	// (and its trailing newline). It captures the group name so it can be
+	// rendered as its own heading instead of folded into a key's
+	// documentation.
+	groupHeaderLine = regexp.MustCompile(`(?m)^[^\S\n]*#[^\S\n]*---\s*(.+?)\s*---[^\S\n]*$\n?`)
+
 	// commentPrefix matches on the YAML comment prefix, e.g.
 	// ```
 	// # comment here
@@ -50,36 +92,112 @@ var (
 	docNodeTmpl = template.Must(
 		template.New("").Parse(
 			strings.Replace(
-				`{{ .LeadingIndent }}- ${{ .Key }}$ ((#v{{ .HTMLAnchor }})){{ if ne .Kind "map" }} (${{ .Kind }}{{ if .FormattedDefault }}: {{ .FormattedDefault }}{{ end }}$){{ end }}{{ if .FormattedDocumentation}} - {{ .FormattedDocumentation }}{{ end }}`,
+				`{{ .LeadingIndent }}- ${{ .Key }}$ ((#v{{ .HTMLAnchor }})){{ if ne .Kind "map" }} (${{ .Kind }}{{ if .FormattedDefault }}: {{ .FormattedDefault }}{{ end }}$){{ end }}{{ if .FormattedDocumentation}} - {{ .FormattedDocumentation }}{{ end }}{{ if .DeprecatedAlert }} {{ .DeprecatedAlert }}{{ end }}{{ if .SinceBadge }} {{ .SinceBadge }}{{ end }}`,
 				"$", "`", -1)),
 	)
 )
 
-// main reads values.yaml and prints the generated documentation to stdout.
+// main dispatches to the "check" subcommand (see check.go) if it's the
+// first argument, and otherwise reads values.yaml and, depending on
+// -format, prints the generated markdown documentation to stdout and/or
+// writes values.schema.json next to it.
+//
+// Usage:
+//
+//	go run ./...                                           # print markdown
+//	go run ./... -format=jsonschema                        # write values.schema.json
+//	go run ./... check [--write] <path-to-markdown-file>   # verify docs are in sync
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		runCheck(os.Args[2:])
+		return
+	}
+
+	format := flag.String("format", "markdown", "output format: markdown, jsonschema, or both")
+	chartsDir := flag.String("charts-dir", "", "also document every charts/*/values.yaml found under this directory, each under its own H2 header")
+	minVersion := flag.String("min-version", "", "leave out keys whose @since annotation is newer than this chart version (keys without @since are always included)")
+	flag.Parse()
+
+	if *format == "markdown" || *format == "both" {
+		out, err := generateReference(*chartsDir, *minVersion)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(out)
+	}
+
+	if *format == "jsonschema" || *format == "both" {
+		inputBytes, err := ioutil.ReadFile("../../values.yaml")
+		if err != nil {
+			log.Fatal(err)
+		}
+		schema, err := GenerateJSONSchema(string(inputBytes))
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := ioutil.WriteFile("../../values.schema.json", []byte(schema+"\n"), 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// generateReference reads ../../values.yaml and renders it as markdown,
+// appending each charts/*/values.yaml found under chartsDir (if any) as its
+// own section. It's shared by main's markdown output and the "check"
+// subcommand, so both compare/publish the exact same document.
+func generateReference(chartsDir, minVersion string) (string, error) {
 	inputBytes, err := ioutil.ReadFile("../../values.yaml")
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
-	out, err := GenerateDocs(string(inputBytes))
+
+	out, err := GenerateDocsForChart(string(inputBytes), "", minVersion)
 	if err != nil {
-		log.Fatal(err)
+		return "", err
+	}
+
+	if chartsDir != "" {
+		subOut, err := subchartDocs(chartsDir, minVersion)
+		if err != nil {
+			return "", err
+		}
+		if subOut != "" {
+			out = out + "\n\n" + subOut
+		}
 	}
-	fmt.Println(out)
+
+	return out, nil
 }
 
+// GenerateDocs renders yamlStr as markdown documentation.
 func GenerateDocs(yamlStr string) (string, error) {
-	node, err := Parse(yamlStr)
+	return GenerateDocsForChart(yamlStr, "", "")
+}
+
+// GenerateDocsForChart is like GenerateDocs but scopes every anchor under
+// sourceFile, the subchart's directory name, so that a key like
+// `global.name` that appears in both the parent chart and a subchart
+// doesn't produce colliding anchors, and, if minVersion is set, prunes any
+// key whose @since annotation is newer than it. Pass "" for the top-level
+// chart and/or to skip version filtering.
+func GenerateDocsForChart(yamlStr, sourceFile, minVersion string) (string, error) {
+	node, err := Parse(yamlStr, sourceFile)
 	if err != nil {
 		return "", err
 	}
 
+	if minVersion != "" {
+		node = filterByMinVersion(node, minVersion)
+	}
+
 	children, err := generateDocsFromNode(docNodeTmpl, node)
 	return strings.ReplaceAll(strings.Join(children, "\n\n"), "[Enterprise Only]", "<EnterpriseAlert inline />"), err
 }
 
-// Parse parses yamlStr into a tree of DocNode's.
-func Parse(yamlStr string) (DocNode, error) {
+// Parse parses yamlStr into a tree of DocNode's. sourceFile identifies
+// which chart yamlStr came from (see GenerateDocsForChart) and is "" for
+// the top-level chart.
+func Parse(yamlStr, sourceFile string) (DocNode, error) {
 	var node yaml.Node
 	err := yaml.Unmarshal([]byte(yamlStr), &node)
 	if err != nil {
@@ -88,7 +206,7 @@ func Parse(yamlStr string) (DocNode, error) {
 
 	// Due to how the YAML is parsed this is the first real node.
 	rootNode := node.Content[0].Content
-	children, err := parseNodeContent(rootNode, "", false)
+	children, err := parseNodeContent(rootNode, "", false, sourceFile)
 	if err != nil {
 		return DocNode{}, err
 	}
@@ -100,7 +218,7 @@ func Parse(yamlStr string) (DocNode, error) {
 
 // parseNodeContent recursively parses the yaml nodes and outputs a DocNode
 // tree.
-func parseNodeContent(nodeContent []*yaml.Node, parentBreadcrumb string, parentWasMap bool) ([]DocNode, error) {
+func parseNodeContent(nodeContent []*yaml.Node, parentBreadcrumb string, parentWasMap bool, sourceFile string) ([]DocNode, error) {
 	var docNodes []DocNode
 
 	// This is a special type of node where it's an array of maps.
@@ -116,7 +234,7 @@ func parseNodeContent(nodeContent []*yaml.Node, parentBreadcrumb string, parentW
 	//
 	// To do that, we actually need to skip the map node.
 	if len(nodeContent) == 1 {
-		return parseNodeContent(nodeContent[0].Content, parentBreadcrumb, true)
+		return parseNodeContent(nodeContent[0].Content, parentBreadcrumb, true, sourceFile)
 	}
 
 	// skipNext is true if we should skip the next node. Due to how the YAML is
@@ -124,17 +242,23 @@ func parseNodeContent(nodeContent []*yaml.Node, parentBreadcrumb string, parentW
 	// doc node out of that so in the loop we look ahead to the next node
 	// and use it to construct our DocNode. Then we can skip it on the next
 	// iteration.
+	//
+	// prevEndLine tracks the last yaml source line used by the previous
+	// sibling so we can tell how many blank lines separate it from the
+	// next one (see leadingBlankLines).
 	skipNext := false
+	prevEndLine := -1
 	for i, child := range nodeContent {
 		if skipNext {
 			skipNext = false
 			continue
 		}
 
-		docNode, err := buildDocNode(i, child, nodeContent, parentBreadcrumb, parentWasMap)
+		docNode, err := buildDocNode(i, child, nodeContent, parentBreadcrumb, parentWasMap, sourceFile)
 		if err != nil {
 			return nil, err
 		}
+		docNode.LeadingBlankLines = leadingBlankLines(prevEndLine, child)
 
 		if err := docNode.Validate(); err != nil {
 			return nil, &ParseError{
@@ -144,15 +268,64 @@ func parseNodeContent(nodeContent []*yaml.Node, parentBreadcrumb string, parentW
 		}
 
 		docNodes = append(docNodes, docNode)
+		if i+1 < len(nodeContent) {
+			prevEndLine = maxLine(nodeContent[i+1])
+		} else {
+			prevEndLine = maxLine(child)
+		}
 		skipNext = true
 		continue
 	}
 	return docNodes, nil
 }
 
+// maxLine returns the highest yaml source line number used anywhere within
+// node, so callers can tell where a (possibly multi-line) node's block
+// ends.
+func maxLine(node *yaml.Node) int {
+	max := node.Line
+	for _, child := range node.Content {
+		if l := maxLine(child); l > max {
+			max = l
+		}
+	}
+	return max
+}
+
+// leadingBlankLines returns how many blank lines separate prevEndLine (the
+// last source line used by the previous sibling) from currNode, ignoring
+// any lines taken up by currNode's own HeadComment.
+func leadingBlankLines(prevEndLine int, currNode *yaml.Node) int {
+	if prevEndLine < 0 {
+		return 0
+	}
+	commentLines := 0
+	if currNode.HeadComment != "" {
+		// HeadComment joins its lines with "\n" but doesn't add a trailing
+		// one, so an N-line comment has only N-1 embedded newlines.
+		commentLines = strings.Count(currNode.HeadComment, "\n") + 1
+	}
+	gap := currNode.Line - commentLines - prevEndLine - 1
+	if gap < 0 {
+		return 0
+	}
+	return gap
+}
+
 func generateDocsFromNode(tm *template.Template, node DocNode) ([]string, error) {
 	var out []string
 	for _, child := range node.Children {
+		// A gap of 2 or more blank lines in values.yaml is treated as the
+		// author grouping keys intentionally, so we preserve it as a
+		// visual break instead of silently collapsing it.
+		if child.LeadingBlankLines >= 2 {
+			if child.GroupName != "" {
+				out = append(out, fmt.Sprintf("#### %s", child.GroupName))
+			} else {
+				out = append(out, "---")
+			}
+		}
+
 		var nodeOut bytes.Buffer
 		err := tm.Execute(&nodeOut, child)
 		if err != nil {
@@ -204,17 +377,22 @@ func toInlineYaml(content []*yaml.Node) (string, error) {
 	return strings.TrimPrefix(string(out), "arr: "), nil
 }
 
-func buildDocNode(nodeContentIdx int, currNode *yaml.Node, nodeContent []*yaml.Node, parentBreadcrumb string, parentWasMap bool) (DocNode, error) {
+func buildDocNode(nodeContentIdx int, currNode *yaml.Node, nodeContent []*yaml.Node, parentBreadcrumb string, parentWasMap bool, sourceFile string) (DocNode, error) {
 	// Check for the @recurse: false annotation.
 	// In this case we construct our node and then don't recurse further.
 	if match := recurseAnnotation.FindStringSubmatch(currNode.HeadComment); len(match) > 0 && match[1] == "false" {
-		return DocNode{
+		docNode := DocNode{
 			Column:           currNode.Column,
 			ParentBreadcrumb: parentBreadcrumb,
 			ParentWasMap:     false,
 			Key:              currNode.Value,
 			Comment:          currNode.HeadComment,
-		}, nil
+			SourceFile:       sourceFile,
+		}
+		applyConstraintAnnotations(&docNode)
+		applyGroupHeader(&docNode)
+		applyLifecycleAnnotations(&docNode)
+		return docNode, nil
 	}
 
 	// Nodes should come in pairs.
@@ -228,11 +406,12 @@ func buildDocNode(nodeContentIdx int, currNode *yaml.Node, nodeContent []*yaml.N
 
 	next := nodeContent[nodeContentIdx+1]
 
+	var docNode DocNode
 	switch next.Kind {
 
 	// If it's a scalar then this is a simple key: value node.
 	case yaml.ScalarNode:
-		return DocNode{
+		docNode = DocNode{
 			ParentBreadcrumb: parentBreadcrumb,
 			ParentWasMap:     parentWasMap,
 			Column:           currNode.Column,
@@ -240,40 +419,42 @@ func buildDocNode(nodeContentIdx int, currNode *yaml.Node, nodeContent []*yaml.N
 			Comment:          currNode.HeadComment,
 			KindTag:          next.Tag,
 			Default:          next.Value,
-		}, nil
+			SourceFile:       sourceFile,
+		}
 
 	// If it's a map then we will need to recurse into it.
 	case yaml.MappingNode:
-		docNode := DocNode{
+		docNode = DocNode{
 			ParentBreadcrumb: parentBreadcrumb,
 			ParentWasMap:     parentWasMap,
 			Column:           currNode.Column,
 			Key:              currNode.Value,
 			Comment:          currNode.HeadComment,
 			KindTag:          next.Tag,
+			SourceFile:       sourceFile,
 		}
 		var err error
-		docNode.Children, err = parseNodeContent(next.Content, docNode.HTMLAnchor(), false)
+		docNode.Children, err = parseNodeContent(next.Content, docNode.HTMLAnchor(), false, sourceFile)
 		if err != nil {
 			return DocNode{}, err
 		}
-		return docNode, nil
 
 	// If it's a sequence, i.e. array, then we have to handle it differently
 	// depending on its contents.
 	case yaml.SequenceNode:
 		// If it's empty then its just a key with a default of empty array.
 		if len(next.Content) == 0 {
-			return DocNode{
+			docNode = DocNode{
 				ParentBreadcrumb: parentBreadcrumb,
 				ParentWasMap:     parentWasMap,
 				Column:           currNode.Column,
 				Key:              currNode.Value,
 				// Default is empty array.
-				Default: "[]",
-				Comment: currNode.HeadComment,
-				KindTag: next.Tag,
-			}, nil
+				Default:    "[]",
+				Comment:    currNode.HeadComment,
+				KindTag:    next.Tag,
+				SourceFile: sourceFile,
+			}
 
 			// If it's full of scalars, e.g. key: [a, b] then we can stop recursing
 			// and use the value as the default.
@@ -286,34 +467,96 @@ func buildDocNode(nodeContentIdx int, currNode *yaml.Node, nodeContent []*yaml.N
 					Err:          err.Error(),
 				}
 			}
-			return DocNode{
+			docNode = DocNode{
 				ParentBreadcrumb: parentBreadcrumb,
 				ParentWasMap:     parentWasMap,
 				Column:           currNode.Column,
 				Key:              currNode.Value,
 				// Default will be the yaml value.
-				Default: inlineYaml,
-				Comment: currNode.HeadComment,
-				KindTag: next.Tag,
-			}, nil
+				Default:    inlineYaml,
+				Comment:    currNode.HeadComment,
+				KindTag:    next.Tag,
+				SourceFile: sourceFile,
+			}
 		} else {
 
 			// Otherwise we need to recurse into each element of the array.
-			docNode := DocNode{
+			docNode = DocNode{
 				ParentBreadcrumb: parentBreadcrumb,
 				ParentWasMap:     parentWasMap,
 				Column:           currNode.Column,
 				Key:              currNode.Value,
 				Comment:          currNode.HeadComment,
 				KindTag:          next.Tag,
+				SourceFile:       sourceFile,
 			}
 			var err error
-			docNode.Children, err = parseNodeContent(next.Content, docNode.HTMLAnchor(), false)
+			docNode.Children, err = parseNodeContent(next.Content, docNode.HTMLAnchor(), false, sourceFile)
 			if err != nil {
 				return DocNode{}, err
 			}
-			return docNode, nil
 		}
+
+	default:
+		return DocNode{}, fmt.Errorf("fell through cases unexpectedly at breadcrumb: %s", parentBreadcrumb)
+	}
+
+	applyConstraintAnnotations(&docNode)
+	applyGroupHeader(&docNode)
+	applyLifecycleAnnotations(&docNode)
+	return docNode, nil
+}
+
+// applyGroupHeader pulls a "# --- Group Name ---" divider line, if present,
+// out of docNode.Comment and records it as docNode.GroupName so it isn't
+// folded into the node's documentation. generateDocsFromNode renders it as
+// its own heading only when the node follows a gap of 2 or more blank lines.
+func applyGroupHeader(docNode *DocNode) {
+	if match := groupHeaderLine.FindStringSubmatch(docNode.Comment); len(match) > 0 {
+		docNode.GroupName = strings.TrimSpace(match[1])
+		docNode.Comment = groupHeaderLine.ReplaceAllString(docNode.Comment, "")
+	}
+}
+
+// applyConstraintAnnotations reads the @enum, @required, @minimum, @maximum
+// and @pattern annotations off of docNode.Comment and sets the
+// corresponding fields. These annotations are consumed by
+// GenerateJSONSchema to produce values.schema.json.
+func applyConstraintAnnotations(docNode *DocNode) {
+	if match := enumAnnotation.FindStringSubmatch(docNode.Comment); len(match) > 0 {
+		var enum []string
+		for _, v := range strings.Split(match[1], ",") {
+			enum = append(enum, strings.TrimSpace(v))
+		}
+		docNode.Enum = enum
+	}
+	if match := requiredAnnotation.FindStringSubmatch(docNode.Comment); len(match) > 0 {
+		docNode.Required = match[1] == "true"
+	}
+	if match := minimumAnnotation.FindStringSubmatch(docNode.Comment); len(match) > 0 {
+		if min, err := strconv.ParseFloat(match[1], 64); err == nil {
+			docNode.Minimum = &min
+		}
+	}
+	if match := maximumAnnotation.FindStringSubmatch(docNode.Comment); len(match) > 0 {
+		if max, err := strconv.ParseFloat(match[1], 64); err == nil {
+			docNode.Maximum = &max
+		}
+	}
+	if match := patternAnnotation.FindStringSubmatch(docNode.Comment); len(match) > 0 {
+		docNode.Pattern = match[1]
+	}
+}
+
+// applyLifecycleAnnotations reads the @deprecated and @since annotations
+// off of docNode.Comment and sets the corresponding fields. They're
+// rendered by docNodeTmpl as a DeprecatedAlert and a "Since" badge, and
+// @since is what -min-version filters on.
+func applyLifecycleAnnotations(docNode *DocNode) {
+	if match := deprecatedAnnotation.FindStringSubmatch(docNode.Comment); len(match) > 0 {
+		docNode.Deprecated = match[1]
+	}
+	if match := sinceAnnotation.FindStringSubmatch(docNode.Comment); len(match) > 0 {
+		docNode.Since = match[1]
 	}
-	return DocNode{}, fmt.Errorf("fell through cases unexpectedly at breadcrumb: %s", parentBreadcrumb)
 }
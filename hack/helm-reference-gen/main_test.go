@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestGenerateDocsForChart_BlankLineGrouping verifies that a single blank
+// line between two documented keys is never rendered as a "---" separator,
+// and that only a deliberate two-or-more-blank-line gap is.
+func TestGenerateDocsForChart_BlankLineGrouping(t *testing.T) {
+	yaml := `global:
+  # a comment
+  a: 1
+
+  # b comment
+  b: 2
+
+
+  # c comment
+  c: 3
+`
+	out, err := GenerateDocsForChart(yaml, "", "")
+	if err != nil {
+		t.Fatalf("GenerateDocsForChart: %v", err)
+	}
+
+	if strings.Contains(out, "a comment") == false {
+		t.Fatalf("expected output to contain rendered docs, got: %s", out)
+	}
+
+	if got := strings.Count(out, "---"); got != 1 {
+		t.Errorf("expected exactly 1 `---` separator (for the 2-blank-line gap before c), got %d:\n%s", got, out)
+	}
+
+	bIdx := strings.Index(out, "- `b`")
+	sepIdx := strings.Index(out, "---")
+	cIdx := strings.Index(out, "- `c`")
+	if !(bIdx >= 0 && sepIdx > bIdx && cIdx > sepIdx) {
+		t.Errorf("expected `---` to appear between b and c, got:\n%s", out)
+	}
+}
+
+// TestGenerateDocsForChart_MultiLineComment verifies that a multi-line
+// HeadComment doesn't itself get misread as a blank-line gap.
+func TestGenerateDocsForChart_MultiLineComment(t *testing.T) {
+	yaml := `global:
+  # a
+  a: 1
+
+  # first line
+  # second line
+  b: 2
+`
+	out, err := GenerateDocsForChart(yaml, "", "")
+	if err != nil {
+		t.Fatalf("GenerateDocsForChart: %v", err)
+	}
+
+	if strings.Contains(out, "---") {
+		t.Errorf("expected no `---` separator for a single blank line, got:\n%s", out)
+	}
+}
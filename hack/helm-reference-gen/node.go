@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DocNode represents a single key from values.yaml along with everything
+// needed to render documentation (or a JSON schema) for it.
+type DocNode struct {
+	// Column is the yaml column the key starts at, used to compute
+	// indentation when rendering.
+	Column int
+
+	// ParentBreadcrumb is the parent node's HTMLAnchor, used to build this
+	// node's own anchor so that nested keys get unique anchors.
+	ParentBreadcrumb string
+
+	// ParentWasMap is true if this node is a key of the map element of a
+	// sequence of maps, e.g. `name` under `ingressGateways`.
+	ParentWasMap bool
+
+	// Key is the yaml key for this node, e.g. "enabled".
+	Key string
+
+	// Comment is the raw HeadComment taken from the yaml node. It may
+	// contain annotations (@type, @default, @recurse, etc) that are
+	// stripped out before being rendered as documentation.
+	Comment string
+
+	// KindTag is the yaml tag of this node's value, e.g. "!!str", "!!map".
+	KindTag string
+
+	// Default is the default value as it appears in values.yaml.
+	Default string
+
+	// Enum is the set of allowed values taken from the @enum annotation.
+	Enum []string
+
+	// Required is true if this key was annotated with @required: true. It
+	// is reflected in the parent's JSON schema "required" list.
+	Required bool
+
+	// Minimum and Maximum come from the @minimum/@maximum annotations.
+	// They're nil unless the annotation is present.
+	Minimum *float64
+	Maximum *float64
+
+	// Pattern comes from the @pattern annotation.
+	Pattern string
+
+	// LeadingBlankLines is the number of blank lines in values.yaml between
+	// this node and its previous sibling. generateDocsFromNode renders a
+	// visual break before the node when it's >= 2.
+	LeadingBlankLines int
+
+	// GroupName is set from a "# --- Group Name ---" divider comment
+	// immediately preceding this node. It's never part of the node's
+	// documentation; it's rendered as a heading above the node only when
+	// LeadingBlankLines >= 2, and otherwise isn't rendered at all.
+	GroupName string
+
+	// SourceFile identifies which chart's values.yaml this node came from,
+	// e.g. "connect-inject" for a subchart discovered via -charts-dir. It's
+	// "" for the top-level chart. It's prefixed onto the top-level anchor
+	// so that a key like global.name doesn't collide between charts.
+	SourceFile string
+
+	// Deprecated is the message from an @deprecated annotation. It's "" if
+	// the key isn't deprecated.
+	Deprecated string
+
+	// Since is the chart version from an @since annotation, the version
+	// this key was introduced in. It's "" if not annotated.
+	Since string
+
+	// Children are any nested DocNode's, e.g. the keys of a map or, for a
+	// sequence of maps, the keys of its element map (not one DocNode per
+	// array entry).
+	Children []DocNode
+}
+
+// HTMLAnchor returns the anchor used to link to this node from the table of
+// contents, e.g. "global-name", or "connect-inject-global-name" for a node
+// sourced from the connect-inject subchart.
+func (d DocNode) HTMLAnchor() string {
+	key := strings.ReplaceAll(d.Key, ".", "")
+	breadcrumb := d.ParentBreadcrumb
+	if breadcrumb == "" && d.SourceFile != "" {
+		breadcrumb = d.SourceFile
+	}
+	if breadcrumb == "" {
+		return key
+	}
+	return fmt.Sprintf("%s-%s", breadcrumb, key)
+}
+
+// Kind returns the human-readable type of this node, e.g. "string", "int",
+// "map". It can be overridden with the @type annotation.
+func (d DocNode) Kind() string {
+	if match := typeAnnotation.FindStringSubmatch(d.Comment); len(match) > 0 {
+		return match[1]
+	}
+	switch d.KindTag {
+	case "!!str":
+		return "string"
+	case "!!int":
+		return "int"
+	case "!!bool":
+		return "boolean"
+	case "!!seq":
+		return "array"
+	case "!!map", "":
+		return "map"
+	default:
+		return "string"
+	}
+}
+
+// FormattedDefault returns the default value to render, preferring an
+// explicit @default annotation over the value found in values.yaml.
+func (d DocNode) FormattedDefault() string {
+	if match := defaultAnnotation.FindStringSubmatch(d.Comment); len(match) > 0 {
+		return match[1]
+	}
+	return d.Default
+}
+
+// DeprecatedAlert renders a DeprecatedAlert MDX component carrying this
+// node's @deprecated message, mirroring the [Enterprise Only] ->
+// <EnterpriseAlert inline /> rewrite GenerateDocs performs for enterprise
+// keys. It's "" unless the node is deprecated.
+func (d DocNode) DeprecatedAlert() string {
+	if d.Deprecated == "" {
+		return ""
+	}
+	return fmt.Sprintf("<DeprecatedAlert inline>%s</DeprecatedAlert>", d.Deprecated)
+}
+
+// SinceBadge renders a "Since vX.Y" badge from this node's @since
+// annotation. It's "" unless the node is annotated.
+func (d DocNode) SinceBadge() string {
+	if d.Since == "" {
+		return ""
+	}
+	return fmt.Sprintf("`Since v%s`", strings.TrimPrefix(d.Since, "v"))
+}
+
+// FormattedDocumentation returns the comment with the yaml comment prefix
+// and any annotations stripped out, ready to be rendered as markdown.
+func (d DocNode) FormattedDocumentation() string {
+	doc := annotationLine.ReplaceAllString(d.Comment, "")
+	doc = commentPrefix.ReplaceAllString(doc, "")
+	return strings.TrimSpace(doc)
+}
+
+// LeadingIndent returns the whitespace to prefix this node's markdown
+// bullet with so that nested keys render as nested lists. It's derived
+// from Column (values.yaml is 2-space indented) rather than from
+// ParentBreadcrumb, since the latter can contain extra dashes from the key
+// name or a SourceFile prefix.
+func (d DocNode) LeadingIndent() string {
+	return strings.Repeat("  ", (d.Column-1)/2)
+}
+
+// Validate returns an error if the node is malformed, e.g. missing a key.
+func (d DocNode) Validate() error {
+	if d.Key == "" && d.ParentBreadcrumb != "" {
+		return &ParseError{
+			ParentAnchor: d.ParentBreadcrumb,
+			Err:          "node is missing a key",
+		}
+	}
+	return nil
+}
+
+// ParseError is returned when the values.yaml file can't be turned into a
+// DocNode tree, e.g. because a key is missing its value.
+type ParseError struct {
+	ParentAnchor string
+	CurrAnchor   string
+	FullAnchor   string
+	Err          string
+}
+
+func (p *ParseError) Error() string {
+	anchor := p.FullAnchor
+	if anchor == "" {
+		anchor = fmt.Sprintf("%s.%s", p.ParentAnchor, p.CurrAnchor)
+	}
+	return fmt.Sprintf("error parsing node at %q: %s", anchor, p.Err)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// filterByMinVersion returns a copy of node with any descendant pruned
+// whose @since annotation is newer than minVersion, so the rendered
+// reference only covers keys that shipped by that chart version.
+func filterByMinVersion(node DocNode, minVersion string) DocNode {
+	var kept []DocNode
+	for _, child := range node.Children {
+		if child.Since != "" && versionNewer(child.Since, minVersion) {
+			continue
+		}
+		kept = append(kept, filterByMinVersion(child, minVersion))
+	}
+	node.Children = kept
+	return node
+}
+
+// versionNewer reports whether version a is newer than version b, e.g.
+// versionNewer("1.10.0", "1.9.0") == true. Both may have an optional
+// leading "v"; missing or non-numeric components are treated as 0.
+func versionNewer(a, b string) bool {
+	aParts := versionParts(a)
+	bParts := versionParts(b)
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var av, bv int
+		if i < len(aParts) {
+			av = aParts[i]
+		}
+		if i < len(bParts) {
+			bv = bParts[i]
+		}
+		if av != bv {
+			return av > bv
+		}
+	}
+	return false
+}
+
+// versionParts splits a version string like "v1.10.2" into [1, 10, 2].
+func versionParts(v string) []int {
+	fields := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	parts := make([]int, len(fields))
+	for i, f := range fields {
+		// Ignore the error; a non-numeric component is treated as 0 rather
+		// than rejecting the whole @since annotation.
+		parts[i], _ = strconv.Atoi(f)
+	}
+	return parts
+}
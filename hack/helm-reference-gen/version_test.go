@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestVersionNewer(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		{"1.10.0", "1.9.0", true},
+		{"1.9.0", "1.10.0", false},
+		{"v1.2.3", "1.2.3", false},
+		{"1.2", "1.2.0", false},
+		{"1.2.1", "1.2", true},
+	}
+	for _, c := range cases {
+		if got := versionNewer(c.a, c.b); got != c.want {
+			t.Errorf("versionNewer(%q, %q) = %v, want %v", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestFilterByMinVersion(t *testing.T) {
+	yaml := `global:
+  # @since: 1.0.0
+  a: 1
+  # @since: 2.0.0
+  b: 2
+`
+	node, err := Parse(yaml, "")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	filtered := filterByMinVersion(node, "1.5.0")
+	global := filtered.Children[0]
+	if len(global.Children) != 1 || global.Children[0].Key != "a" {
+		t.Errorf("expected only `a` to survive filtering to 1.5.0, got %v", global.Children)
+	}
+}